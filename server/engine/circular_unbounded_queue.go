@@ -0,0 +1,115 @@
+package engine
+
+// CircularUnboundedQueue is a ring-buffer backed queue used by
+// UnboundedStreamImpl to absorb bursts of entries that the small `in`/`out`
+// channels can't hold. It grows geometrically (doubling) as it fills up, up
+// to maxCapacity, and shrinks back down once usage drops to a quarter of the
+// current backing array, so a burst doesn't leave a permanently oversized
+// allocation behind.
+type CircularUnboundedQueue struct {
+	buf   []StreamEntry
+	head  int
+	count int
+	max   int
+}
+
+// NewCircularUnboundedQueue creates a queue starting at initialCapacity that
+// never grows past maxCapacity.
+func NewCircularUnboundedQueue(initialCapacity, maxCapacity int) *CircularUnboundedQueue {
+	if initialCapacity < 1 {
+		initialCapacity = 1
+	}
+	if maxCapacity < initialCapacity {
+		maxCapacity = initialCapacity
+	}
+	return &CircularUnboundedQueue{
+		buf: make([]StreamEntry, initialCapacity),
+		max: maxCapacity,
+	}
+}
+
+// Len returns the number of entries currently queued.
+func (q *CircularUnboundedQueue) Len() int {
+	return q.count
+}
+
+// Cap returns the current backing array size (not the max it can grow to).
+func (q *CircularUnboundedQueue) Cap() int {
+	return len(q.buf)
+}
+
+// Full reports whether the queue has reached maxCapacity and cannot grow further.
+func (q *CircularUnboundedQueue) Full() bool {
+	return q.count >= q.max
+}
+
+// Push appends entry to the queue, growing the backing array if necessary.
+// It returns false if the queue is already at maxCapacity.
+func (q *CircularUnboundedQueue) Push(entry StreamEntry) bool {
+	if q.count == len(q.buf) {
+		if len(q.buf) >= q.max {
+			return false
+		}
+		q.resize(q.growTarget())
+	}
+	tail := (q.head + q.count) % len(q.buf)
+	q.buf[tail] = entry
+	q.count++
+	return true
+}
+
+// Pop removes and returns the oldest entry, shrinking the backing array if
+// usage has dropped enough.
+func (q *CircularUnboundedQueue) Pop() (StreamEntry, bool) {
+	if q.count == 0 {
+		return StreamEntry{}, false
+	}
+	entry := q.buf[q.head]
+	q.buf[q.head] = StreamEntry{}
+	q.head = (q.head + 1) % len(q.buf)
+	q.count--
+
+	if shrinkTo := q.shrinkTarget(); shrinkTo > 0 {
+		q.resize(shrinkTo)
+	}
+	return entry, true
+}
+
+func (q *CircularUnboundedQueue) growTarget() int {
+	newCap := len(q.buf) * 2
+	if newCap == 0 {
+		newCap = 1
+	}
+	if newCap > q.max {
+		newCap = q.max
+	}
+	return newCap
+}
+
+// shrinkTarget returns the new size the backing array should shrink to once
+// usage has dropped to a quarter of its capacity, or 0 if no shrink is due.
+func (q *CircularUnboundedQueue) shrinkTarget() int {
+	if len(q.buf) <= 1 || q.count == 0 {
+		return 0
+	}
+	if q.count > len(q.buf)/4 {
+		return 0
+	}
+	newCap := len(q.buf) / 2
+	if newCap < q.count {
+		newCap = q.count
+	}
+	if newCap == len(q.buf) {
+		return 0
+	}
+	return newCap
+}
+
+func (q *CircularUnboundedQueue) resize(newCap int) {
+	newBuf := make([]StreamEntry, newCap)
+	for i := 0; i < q.count; i++ {
+		newBuf[i] = q.buf[(q.head+i)%len(q.buf)]
+	}
+	q.buf = newBuf
+	q.head = 0
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircularUnboundedQueueGrows(t *testing.T) {
+	q := NewCircularUnboundedQueue(2, 8)
+	for i := 0; i < 5; i++ {
+		if !q.Push(StreamEntry{Output: "entry"}) {
+			t.Fatalf("Push %d: want ok, got full", i)
+		}
+	}
+	if got, want := q.Len(), 5; got != want {
+		t.Fatalf("Len() = %d, want %d", got, want)
+	}
+	if got := q.Cap(); got < 5 || got > 8 {
+		t.Fatalf("Cap() = %d, want between 5 and 8 after growing", got)
+	}
+}
+
+func TestCircularUnboundedQueueFullAtMax(t *testing.T) {
+	q := NewCircularUnboundedQueue(1, 2)
+	if !q.Push(StreamEntry{Output: "a"}) {
+		t.Fatal("Push 1: want ok")
+	}
+	if !q.Push(StreamEntry{Output: "b"}) {
+		t.Fatal("Push 2: want ok")
+	}
+	if !q.Full() {
+		t.Fatal("Full() = false, want true at maxCapacity")
+	}
+	if q.Push(StreamEntry{Output: "c"}) {
+		t.Fatal("Push at maxCapacity: want false")
+	}
+}
+
+func TestCircularUnboundedQueueShrinksAfterDrain(t *testing.T) {
+	q := NewCircularUnboundedQueue(1, 64)
+	for i := 0; i < 16; i++ {
+		if !q.Push(StreamEntry{Output: "entry", Timestamp: time.Unix(int64(i), 0)}) {
+			t.Fatalf("Push %d: want ok", i)
+		}
+	}
+	grownCap := q.Cap()
+
+	// Popping down to a quarter of the grown capacity should shrink the
+	// backing array back down rather than holding onto the burst-sized one.
+	for q.Len() > grownCap/4 {
+		if _, ok := q.Pop(); !ok {
+			t.Fatal("Pop: want ok while Len() > 0")
+		}
+	}
+	if got := q.Cap(); got >= grownCap {
+		t.Fatalf("Cap() = %d, want less than grown capacity %d after draining", got, grownCap)
+	}
+
+	// The remaining entries must still come out in FIFO order after the
+	// resize that shrinking performs under the hood.
+	next := int64(16 - q.Len())
+	for q.Len() > 0 {
+		entry, ok := q.Pop()
+		if !ok {
+			t.Fatal("Pop: want ok while Len() > 0")
+		}
+		if got := entry.Timestamp.Unix(); got != next {
+			t.Fatalf("Pop = entry %d, want entry %d (FIFO order)", got, next)
+		}
+		next++
+	}
+	if _, ok := q.Pop(); ok {
+		t.Fatal("Pop on empty queue: want ok=false")
+	}
+}
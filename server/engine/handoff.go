@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrHandoffTimeout is wrapped by the error Handoff returns when deadline
+// elapses, or the target stream's own backpressure times out, before every
+// pending entry could be transferred.
+var ErrHandoffTimeout = errors.New("handoff deadline exceeded")
+
+// Handoff drains this stream's pending entries into target, preserving each
+// entry's original UUID and timestamp, so a cluster rebalancer can migrate a
+// stream to its new owning node without losing anything already buffered.
+// It blocks new Sends immediately (the same first step Stop takes), then
+// transfers entries one at a time until either everything has moved or
+// deadline elapses. On a timeout, transferred reports how many entries made
+// it across so the caller can retry the remainder against a (possibly
+// different) target. Handoff does not stop a running StartReadAhead
+// prefetcher: entries it has taken out of outputs are not transferred and stay
+// in this stream's prefetch buffer, where ReceiveBatch returns them.
+func (i *InMemoryStreamImpl) Handoff(target InMemoeryStream, deadline time.Duration) (transferred int, err error) {
+	i.Lock()
+	if !i.stopped {
+		i.stopped = true
+		close(i.stopCh)
+	}
+	i.Unlock()
+
+	deadlineAt := time.Now().Add(deadline)
+	for {
+		select {
+		case entry, ok := <-i.outputs:
+			if !ok {
+				return transferred, nil
+			}
+
+			remaining := time.Until(deadlineAt)
+			if remaining <= 0 {
+				// Couldn't transfer it in time: it must not vanish, so put it
+				// back before reporting how far we got.
+				i.requeue(entry)
+				return transferred, fmt.Errorf("%w: transferred %d entries before the deadline", ErrHandoffTimeout, transferred)
+			}
+			timeoutSeconds := int(remaining.Seconds())
+			if timeoutSeconds < 1 {
+				timeoutSeconds = 1
+			}
+
+			errType, sendErr := target.Send(entry.Output, entry.OutputUUID, entry.Timestamp, timeoutSeconds)
+			if sendErr != nil {
+				// target rejected it (backpressure or otherwise): it's still
+				// only here, not there, so put it back rather than drop it.
+				i.requeue(entry)
+				if errType == ErrorTypeWaitingTimeout {
+					sendErr = fmt.Errorf("%w: %v", ErrHandoffTimeout, sendErr)
+				}
+				return transferred, sendErr
+			}
+			if i.memoryManager != nil {
+				i.memoryManager.Release(approxEntrySize(entry.Output))
+			}
+			transferred++
+		default:
+			// Drained: safe to close, mirroring Stop's final step.
+			i.Lock()
+			if !i.outputsClosed {
+				i.outputsClosed = true
+				close(i.outputs)
+			}
+			i.Unlock()
+			return transferred, nil
+		}
+	}
+}
+
+// requeue puts entry back into outputs after Handoff dequeued it but failed
+// to land it in target, so a failed transfer never just disappears. Handoff
+// has already blocked new Sends, so there's normally room; best-effort if
+// somehow there isn't.
+func (i *InMemoryStreamImpl) requeue(entry StreamEntry) {
+	select {
+	case i.outputs <- entry:
+	default:
+	}
+}
+
+// drainAvailableLocked removes and returns every entry currently buffered in
+// outputs without blocking, so it can't deadlock against a concurrent
+// Receive (which reads i.outputs without taking this lock). Callers must
+// hold the write lock.
+func (i *InMemoryStreamImpl) drainAvailableLocked() []StreamEntry {
+	var entries []StreamEntry
+	for {
+		select {
+		case entry, ok := <-i.outputs:
+			if !ok {
+				return entries
+			}
+			entries = append(entries, entry)
+		default:
+			return entries
+		}
+	}
+}
+
+// refillLocked pushes entries back into outputs, best-effort. Callers must
+// hold the write lock.
+func (i *InMemoryStreamImpl) refillLocked(entries []StreamEntry) {
+	for _, entry := range entries {
+		select {
+		case i.outputs <- entry:
+		default:
+		}
+	}
+}
+
+// Peek returns a snapshot of entries currently buffered in outputs, without
+// consuming them. It's meant for tests and observability, not the hot path:
+// it briefly drains and refills the channel under the write lock.
+func (i *InMemoryStreamImpl) Peek() []StreamEntry {
+	i.Lock()
+	defer i.Unlock()
+
+	entries := i.drainAvailableLocked()
+	i.refillLocked(entries)
+	return entries
+}
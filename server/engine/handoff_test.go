@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestHandoffTransfersAllPendingEntries(t *testing.T) {
+	source := NewInMemoryStreamImpl(8).(*InMemoryStreamImpl)
+	target := NewInMemoryStreamImpl(8).(*InMemoryStreamImpl)
+
+	for i := 0; i < 3; i++ {
+		if _, err := source.Send("entry", uuid.New(), time.Now(), 0); err != nil {
+			t.Fatalf("Send %d: %v", i, err)
+		}
+	}
+
+	transferred, err := source.Handoff(target, time.Second)
+	if err != nil {
+		t.Fatalf("Handoff: %v", err)
+	}
+	if transferred != 3 {
+		t.Fatalf("transferred = %d, want 3", transferred)
+	}
+	if got := len(target.Peek()); got != 3 {
+		t.Fatalf("target has %d entries, want 3", got)
+	}
+}
+
+func TestHandoffRequeuesOnTimeoutInsteadOfDropping(t *testing.T) {
+	source := NewInMemoryStreamImpl(8).(*InMemoryStreamImpl)
+	target := NewInMemoryStreamImpl(8)
+
+	if _, err := source.Send("entry", uuid.New(), time.Now(), 0); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	// A zero deadline expires before the first entry can be transferred,
+	// exercising Handoff's timeout path.
+	_, err := source.Handoff(target, 0)
+	if err == nil {
+		t.Fatal("Handoff with a zero deadline: want timeout error")
+	}
+
+	// The entry that couldn't be transferred must still be here, not
+	// silently dropped.
+	if got := len(source.Peek()); got != 1 {
+		t.Fatalf("source has %d entries after failed handoff, want 1 (requeued)", got)
+	}
+}
@@ -24,6 +24,21 @@ type InMemoryStreamImpl struct {
 	capacity int
 	// channel to signal stop
 	stopCh chan struct{}
+	// channel closed (and replaced) by Reset to wake anything blocked on the
+	// outputs channel it just swapped out
+	resetCh     chan struct{}
+	resetReason error
+	// optional admission control across streams; nil means no memory accounting
+	memoryManager MemoryManager
+	// replay history for Subscribe fan-out, lazily sized on first use
+	history     []historyEntry
+	nextSeq     uint64
+	oldestSeq   uint64
+	subscribers map[string]*subscriberState
+	// guards against double-closing outputs from both Stop and Handoff
+	outputsClosed bool
+	// background prefetcher started by StartReadAhead, nil if never started
+	prefetch *prefetchBuffer
 	// protect the channel and state
 	sync.RWMutex
 }
@@ -42,6 +57,22 @@ func NewInMemoryStreamImpl(size int) InMemoeryStream {
 		capacity: size,
 		stopped:  false,
 		stopCh:   make(chan struct{}),
+		resetCh:  make(chan struct{}),
+	}
+}
+
+// NewInMemoryStreamImplWithMemory is like NewInMemoryStreamImpl but additionally
+// reserves/releases approximate byte-size accounting against mm on every
+// Send/Receive/eviction, so callers can cap total memory held across many
+// streams rather than only per-stream entry counts.
+func NewInMemoryStreamImplWithMemory(size int, mm MemoryManager) InMemoeryStream {
+	return &InMemoryStreamImpl{
+		outputs:       make(chan StreamEntry, size),
+		capacity:      size,
+		stopped:       false,
+		stopCh:        make(chan struct{}),
+		resetCh:       make(chan struct{}),
+		memoryManager: mm,
 	}
 }
 
@@ -58,19 +89,85 @@ func (i *InMemoryStreamImpl) Send(output OutputType, outputUuid uuid.UUID, times
 		Timestamp:  timestamp,
 	}
 
+	var reservedSize int
+	if i.memoryManager != nil {
+		reservedSize = approxEntrySize(output)
+		if err := i.memoryManager.Reserve(reservedSize, i.reservePriority()); err != nil {
+			return ErrorTypeMemoryLimitExceeded, err
+		}
+	}
+
+	// Capture outputs/resetCh once so a concurrent Reset swapping them out
+	// from under us can still wake this call via the generation it captured.
+	i.RLock()
+	outputsChan := i.outputs
+	resetCh := i.resetCh
+	i.RUnlock()
+
 	// If blockingWriteTimeoutSeconds is 0 or not specified, use circular buffer mode
 	if blockingWriteTimeoutSeconds <= 0 {
-		return i.sendCircularBufferWithChannel(entry, i.outputs)
+		errType, sendErr := i.sendCircularBufferWithChannel(entry, outputsChan, resetCh)
+		if sendErr != nil && i.memoryManager != nil {
+			// Never made it into outputs, so give back the reservation.
+			i.memoryManager.Release(reservedSize)
+		}
+		if errType == ErrorTypeNone {
+			i.recordHistory(entry)
+		}
+		return errType, sendErr
 	}
 
 	// Use blocking queue mode with timeout
-	return i.sendBlockingQueueWithChannel(entry, blockingWriteTimeoutSeconds, i.outputs)
+	errType, sendErr := i.sendBlockingQueueWithChannel(entry, blockingWriteTimeoutSeconds, outputsChan, resetCh)
+	if sendErr != nil && i.memoryManager != nil {
+		i.memoryManager.Release(reservedSize)
+	}
+	if errType == ErrorTypeNone {
+		i.recordHistory(entry)
+	}
+	return errType, sendErr
+}
+
+// reservePriority returns PriorityLow once the stream is more than half full
+// (the "soft threshold"), so a MemoryManager under pressure sheds growth
+// from busy streams before it starts refusing brand-new ones.
+func (i *InMemoryStreamImpl) reservePriority() Priority {
+	if i.capacity > 0 && len(i.outputs) >= i.capacity/2 {
+		return PriorityLow
+	}
+	return PriorityHigh
 }
 
 // sendCircularBufferWithChannel implements circular buffer behavior - overwrites oldest data when full
-func (i *InMemoryStreamImpl) sendCircularBufferWithChannel(entry StreamEntry, outputsChan chan StreamEntry) (errorType ErrorType, err error) {
+func (i *InMemoryStreamImpl) sendCircularBufferWithChannel(entry StreamEntry, outputsChan chan StreamEntry, resetCh chan struct{}) (errorType ErrorType, err error) {
+	var onEvict func(StreamEntry)
+	if i.memoryManager != nil {
+		onEvict = func(evicted StreamEntry) {
+			i.memoryManager.Release(approxEntrySize(evicted.Output))
+		}
+	}
+	return sendCircularBuffer(entry, outputsChan, i.stopCh, resetCh, i.capacity, &i.RWMutex, func() bool { return i.stopped }, i.resetError, onEvict)
+}
+
+// sendBlockingQueueWithChannel implements blocking queue behavior - waits for space and returns error on timeout
+func (i *InMemoryStreamImpl) sendBlockingQueueWithChannel(entry StreamEntry, timeoutSeconds int, outputsChan chan StreamEntry, resetCh chan struct{}) (errorType ErrorType, err error) {
+	return sendBlockingQueue(entry, outputsChan, i.stopCh, resetCh, timeoutSeconds, i.resetError)
+}
+
+// resetError builds the error Send/Receive return once resetCh fires,
+// wrapping both ErrStreamReset and the reason passed to Reset.
+func (i *InMemoryStreamImpl) resetError() error {
+	i.RLock()
+	defer i.RUnlock()
+	return fmt.Errorf("%w: %w", ErrStreamReset, i.resetReason)
+}
+
+// sendCircularBuffer is the channel-agnostic core of sendCircularBufferWithChannel,
+// pulled out so other InMemoeryStream implementations (e.g. the unbounded stream's
+// overflow fallback) can reuse the same overwrite-oldest semantics on their own channel.
+func sendCircularBuffer(entry StreamEntry, outputsChan chan StreamEntry, stopCh, resetCh chan struct{}, capacity int, mu *sync.RWMutex, isStopped func() bool, resetErr func() error, onEvict func(StreamEntry)) (errorType ErrorType, err error) {
 	// Not allowed for zero capacity circular buffer
-	if i.capacity == 0 {
+	if capacity == 0 {
 		return ErrorTypeInvalidRequest, errors.New("zero capacity circular buffer is not allowed")
 	}
 
@@ -78,16 +175,18 @@ func (i *InMemoryStreamImpl) sendCircularBufferWithChannel(entry StreamEntry, ou
 	case outputsChan <- entry:
 		// Successfully wrote to channel
 		return ErrorTypeNone, nil
-	case <-i.stopCh:
+	case <-stopCh:
 		return ErrorTypeStreamStopped, ErrStreamStopped
+	case <-resetCh:
+		return ErrorTypeStreamReset, resetErr()
 	default:
 		// Channel is full, remove oldest entry and add new one
 		// Use write lock to protect the two operations below
-		i.Lock()
-		defer i.Unlock()
+		mu.Lock()
+		defer mu.Unlock()
 
 		// Check if stopped while waiting for lock
-		if i.stopped {
+		if isStopped() {
 			return ErrorTypeStreamStopped, ErrStreamStopped
 		}
 
@@ -98,13 +197,23 @@ func (i *InMemoryStreamImpl) sendCircularBufferWithChannel(entry StreamEntry, ou
 				return ErrorTypeCircularBufferIterationLimit, fmt.Errorf("failed to write to circular buffer, buffer is still full after removing oldest entry for %d iterations", iterations)
 			}
 			// However, this is best effort only because other operations are not using locks.
-			<-outputsChan // Remove oldest
+			evicted := <-outputsChan // Remove oldest
+			if onEvict != nil {
+				onEvict(evicted)
+			}
 			select {
 			case outputsChan <- entry:
 				// Successfully wrote to channel
 				return ErrorTypeNone, nil
-			case <-i.stopCh:
+			case <-stopCh:
 				return ErrorTypeStreamStopped, ErrStreamStopped
+			case <-resetCh:
+				// resetErr takes the read lock, so it can't run while mu is
+				// still held for writing here.
+				mu.Unlock()
+				resetErrValue := resetErr()
+				mu.Lock()
+				return ErrorTypeStreamReset, resetErrValue
 			default:
 				// Channel is still full, do it again
 				continue
@@ -113,14 +222,16 @@ func (i *InMemoryStreamImpl) sendCircularBufferWithChannel(entry StreamEntry, ou
 	}
 }
 
-// sendBlockingQueueWithChannel implements blocking queue behavior - waits for space and returns error on timeout
-func (i *InMemoryStreamImpl) sendBlockingQueueWithChannel(entry StreamEntry, timeoutSeconds int, outputsChan chan StreamEntry) (errorType ErrorType, err error) {
+// sendBlockingQueue is the channel-agnostic core of sendBlockingQueueWithChannel.
+func sendBlockingQueue(entry StreamEntry, outputsChan chan StreamEntry, stopCh, resetCh chan struct{}, timeoutSeconds int, resetErr func() error) (errorType ErrorType, err error) {
 	select {
 	case outputsChan <- entry:
 		// Successfully wrote to channel
 		return ErrorTypeNone, nil
-	case <-i.stopCh:
+	case <-stopCh:
 		return ErrorTypeStreamStopped, ErrStreamStopped
+	case <-resetCh:
+		return ErrorTypeStreamReset, resetErr()
 	case <-time.After(time.Duration(timeoutSeconds) * time.Second):
 		// NOTE: As of Go 1.23, the garbage collector can recover unreferenced unstopped timers. There is no reason to prefer NewTimer when After will do.
 		return ErrorTypeWaitingTimeout, errors.New("timeout waiting for stream space (424)")
@@ -134,8 +245,16 @@ func (i *InMemoryStreamImpl) Receive(timeoutSeconds int) (output *InternalReceiv
 		return nil, ErrorTypeStreamStopped, ErrStreamStopped
 	}
 
+	i.RLock()
+	outputsChan := i.outputs
+	resetCh := i.resetCh
+	i.RUnlock()
+
 	select {
-	case entry := <-i.outputs:
+	case entry := <-outputsChan:
+		if i.memoryManager != nil {
+			i.memoryManager.Release(approxEntrySize(entry.Output))
+		}
 		// Successfully received an entry
 		return &InternalReceiveResponse{
 			OutputUuid: entry.OutputUUID,
@@ -144,6 +263,8 @@ func (i *InMemoryStreamImpl) Receive(timeoutSeconds int) (output *InternalReceiv
 		}, ErrorTypeNone, nil
 	case <-i.stopCh:
 		return nil, ErrorTypeStreamStopped, ErrStreamStopped
+	case <-resetCh:
+		return nil, ErrorTypeStreamReset, i.resetError()
 	case <-time.After(time.Duration(timeoutSeconds) * time.Second):
 		// NOTE: As of Go 1.23, the garbage collector can recover unreferenced unstopped timers. There is no reason to prefer NewTimer when After will do.
 		return nil, ErrorTypeWaitingTimeout, nil
@@ -153,15 +274,38 @@ func (i *InMemoryStreamImpl) Receive(timeoutSeconds int) (output *InternalReceiv
 // Stop implements InMemoeryStream.
 func (i *InMemoryStreamImpl) Stop() error {
 	i.Lock()
-	defer i.Unlock()
-
 	if i.stopped {
+		i.Unlock()
 		return nil
 	}
 
 	i.stopped = true
-	close(i.stopCh) 
-	// TODO move the received outputs to the new node that owned the streamId
+	close(i.stopCh)
+
+	// Account for whatever's buffered without consuming it, so the contract
+	// below (pending entries stay readable) holds whether or not a
+	// MemoryManager is configured, and so this can't race a concurrently
+	// blocked Receive over the same entries.
+	if i.memoryManager != nil {
+		buffered := i.drainAvailableLocked()
+		for _, entry := range buffered {
+			i.memoryManager.Release(approxEntrySize(entry.Output))
+		}
+		i.refillLocked(buffered)
+	}
+
+	// Leaves pending entries in outputs for an already-blocked Receive to
+	// drain; callers that need those entries preserved elsewhere (e.g. a
+	// cluster rebalancer moving a stream to its new owning node) should use
+	// Handoff instead of Stop.
+	i.outputsClosed = true
 	close(i.outputs)
+	i.Unlock()
+
+	// Stop the prefetcher. The entries it still had buffered are returned by
+	// Drain and discarded here, so unlike the entries left in outputs above
+	// they are not readable after Stop.
+	i.Drain()
+
 	return nil
 }
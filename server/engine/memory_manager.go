@@ -0,0 +1,74 @@
+package engine
+
+import "fmt"
+
+// Priority indicates how readily a MemoryManager may deny a Reserve call
+// under pressure. PriorityHigh is used for newly accepted entries;
+// PriorityLow is used once a stream has grown past its soft threshold, so a
+// MemoryManager can shed further growth from busy streams before it starts
+// refusing brand-new entries.
+type Priority int
+
+const (
+	PriorityHigh Priority = iota
+	PriorityLow
+)
+
+// MemoryManager accounts for the approximate number of bytes held across
+// (potentially many) streams in a process, so operators can cap total memory
+// instead of only per-stream entry counts. Implementations must be safe for
+// concurrent use.
+type MemoryManager interface {
+	// Reserve accounts for size bytes before they are enqueued. It returns
+	// an error (surfaced by Send as ErrorTypeMemoryLimitExceeded) if the
+	// reservation would exceed the manager's limit.
+	Reserve(size int, priority Priority) error
+	// Release gives back size bytes previously accounted for by Reserve.
+	Release(size int)
+}
+
+// ErrMemoryLimitExceeded is the error a MemoryManager should wrap or return
+// as-is from Reserve when a reservation can't be satisfied.
+var ErrMemoryLimitExceeded = fmt.Errorf("memory limit exceeded")
+
+// ErrorTypeMemoryLimitExceeded is returned by Send when a MemoryManager's
+// Reserve call fails admission for the entry.
+const ErrorTypeMemoryLimitExceeded ErrorType = "MEMORY_LIMIT_EXCEEDED"
+
+// Rough, fixed overhead estimates for the bookkeeping fields of a
+// StreamEntry; OutputType's own contribution is measured separately since
+// its concrete size varies per entry.
+const (
+	uuidOverheadBytes      = 16
+	timestampOverheadBytes = 24
+
+	// approxOutputFallbackBytes is used for OutputType values whose
+	// concrete shape isn't one of the cheap cases approxOutputSize knows
+	// how to measure directly.
+	approxOutputFallbackBytes = 64
+)
+
+// approxEntrySize estimates the number of bytes a StreamEntry occupies, used
+// to size Reserve/Release calls around Send/Receive/eviction.
+func approxEntrySize(output OutputType) int {
+	return uuidOverheadBytes + timestampOverheadBytes + approxOutputSize(output)
+}
+
+// approxOutputSize estimates OutputType's contribution to entry size. It
+// switches on the shapes OutputType is commonly sent as rather than
+// formatting the value (len(fmt.Sprint(output)) renders the whole payload
+// to a throwaway string on every Send/Receive/eviction, which is both an
+// O(n) allocation on the hot path and a poor proxy for in-memory bytes).
+// Anything else falls back to a fixed estimate.
+func approxOutputSize(output OutputType) int {
+	switch v := any(output).(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case fmt.Stringer:
+		return len(v.String())
+	default:
+		return approxOutputFallbackBytes
+	}
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeMemoryManager tracks total bytes reserved/released so tests can assert
+// Send/Stop/Receive keep the two in balance.
+type fakeMemoryManager struct {
+	reserved int
+	released int
+}
+
+func (f *fakeMemoryManager) Reserve(size int, priority Priority) error {
+	f.reserved += size
+	return nil
+}
+
+func (f *fakeMemoryManager) Release(size int) {
+	f.released += size
+}
+
+func TestStopReleasesMemoryWithoutDiscardingBufferedEntries(t *testing.T) {
+	mm := &fakeMemoryManager{}
+	stream := NewInMemoryStreamImplWithMemory(4, mm)
+
+	for i := 0; i < 3; i++ {
+		if _, err := stream.Send("entry", uuid.New(), time.Now(), 0); err != nil {
+			t.Fatalf("Send %d: %v", i, err)
+		}
+	}
+
+	impl := stream.(*InMemoryStreamImpl)
+	if err := impl.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	// Stop must release the buffered entries' reservations without consuming
+	// them, so they stay readable afterwards.
+	if mm.released != mm.reserved {
+		t.Fatalf("released = %d, want to match reserved = %d", mm.released, mm.reserved)
+	}
+
+	count := 0
+	for range impl.outputs {
+		count++
+	}
+	if count != 3 {
+		t.Fatalf("entries readable after Stop = %d, want 3", count)
+	}
+}
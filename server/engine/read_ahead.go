@@ -0,0 +1,326 @@
+package engine
+
+import (
+	"sync"
+	"time"
+)
+
+// readAheadBatchSize bounds how many entries the prefetcher groups together
+// per internal batch before handing it to a waiting ReceiveBatch caller.
+const readAheadBatchSize = 16
+
+// prefetchBuffer holds batches drained ahead of time by the goroutine
+// StartReadAhead spawns. window is the current soft-start limit on how many
+// batches may be buffered at once; it grows toward target as ReceiveBatch
+// calls keep draining it, so an idle stream's prefetcher doesn't pre-fill
+// target batches for nobody to read.
+type prefetchBuffer struct {
+	sync.Mutex
+	batches [][]StreamEntry
+	window  int
+	target  int
+
+	notify chan struct{} // signaled when a batch is pushed
+	roomCh chan struct{} // signaled when a batch is popped, freeing window room
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+func (pf *prefetchBuffer) push(batch []StreamEntry) {
+	if len(batch) == 0 {
+		return
+	}
+	pf.Lock()
+	if len(pf.batches) == 0 && pf.window < pf.target {
+		pf.window++
+	}
+	pf.batches = append(pf.batches, batch)
+	pf.Unlock()
+
+	select {
+	case pf.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (pf *prefetchBuffer) popUpTo(maxItems int) []StreamEntry {
+	pf.Lock()
+	result := make([]StreamEntry, 0, maxItems)
+	poppedBatch := false
+	for len(result) < maxItems && len(pf.batches) > 0 {
+		b := pf.batches[0]
+		take := maxItems - len(result)
+		if take >= len(b) {
+			result = append(result, b...)
+			pf.batches = pf.batches[1:]
+			poppedBatch = true
+		} else {
+			result = append(result, b[:take]...)
+			pf.batches[0] = b[take:]
+		}
+	}
+	pf.Unlock()
+
+	if poppedBatch {
+		select {
+		case pf.roomCh <- struct{}{}:
+		default:
+		}
+	}
+	return result
+}
+
+// drainAll returns every still-buffered entry and empties the buffer.
+func (pf *prefetchBuffer) drainAll() []StreamEntry {
+	pf.Lock()
+	defer pf.Unlock()
+	var result []StreamEntry
+	for _, b := range pf.batches {
+		result = append(result, b...)
+	}
+	pf.batches = nil
+	return result
+}
+
+func (pf *prefetchBuffer) isFull() bool {
+	pf.Lock()
+	defer pf.Unlock()
+	return len(pf.batches) >= pf.window
+}
+
+// StartReadAhead spawns a single background goroutine that drains i.outputs
+// into an internal slice-of-slices buffer ahead of demand, so ReceiveBatch
+// can serve handlers without waiting on channel ops in the hot path. The
+// prefetch window starts small and grows to bufferCount only as callers keep
+// pace, to avoid wasting memory on idle streams. Calling it again while
+// already running is a no-op.
+func (i *InMemoryStreamImpl) StartReadAhead(bufferCount int) {
+	if bufferCount < 1 {
+		bufferCount = 1
+	}
+
+	i.Lock()
+	if i.prefetch != nil {
+		i.Unlock()
+		return
+	}
+	pf := &prefetchBuffer{
+		target: bufferCount,
+		window: 1,
+		notify: make(chan struct{}, 1),
+		roomCh: make(chan struct{}, 1),
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	i.prefetch = pf
+	i.Unlock()
+
+	go i.runReadAhead(pf)
+}
+
+func (i *InMemoryStreamImpl) runReadAhead(pf *prefetchBuffer) {
+	defer close(pf.done)
+	for {
+		if !pf.waitForRoom() {
+			return
+		}
+		batch, ok := i.fillBatch(pf)
+		pf.push(batch)
+		if !ok {
+			return
+		}
+	}
+}
+
+// waitForRoom blocks until the buffer has room under its current window, or
+// the prefetcher is told to stop.
+func (pf *prefetchBuffer) waitForRoom() bool {
+	for pf.isFull() {
+		select {
+		case <-pf.roomCh:
+		case <-pf.stopCh:
+			return false
+		}
+	}
+	return true
+}
+
+// fillBatch blocks for at least one entry (or stop/close), then
+// opportunistically drains more without blocking, up to readAheadBatchSize,
+// so a quiet stream's prefetcher doesn't spin. It captures i.outputs/
+// i.resetCh under the read lock rather than reading the fields directly
+// (Reset reassigns both under the write lock), and picks up the freshly
+// swapped-in channel after a Reset instead of staying blocked on the one
+// Reset just discarded.
+func (i *InMemoryStreamImpl) fillBatch(pf *prefetchBuffer) ([]StreamEntry, bool) {
+	var batch []StreamEntry
+
+	for {
+		i.RLock()
+		outputsChan := i.outputs
+		resetCh := i.resetCh
+		i.RUnlock()
+
+		select {
+		case entry, ok := <-outputsChan:
+			if !ok {
+				return batch, false
+			}
+			batch = append(batch, entry)
+		case <-resetCh:
+			continue
+		case <-pf.stopCh:
+			return batch, false
+		}
+		break
+	}
+
+	for len(batch) < readAheadBatchSize {
+		i.RLock()
+		outputsChan := i.outputs
+		i.RUnlock()
+
+		select {
+		case entry, ok := <-outputsChan:
+			if !ok {
+				return batch, false
+			}
+			batch = append(batch, entry)
+		default:
+			return batch, true
+		}
+	}
+	return batch, true
+}
+
+// Drain stops the prefetcher (if one is running) and returns whatever it
+// still had buffered, without waiting for more. It's used by Stop to wind
+// down cleanly, and is safe to call even if StartReadAhead was never called.
+func (i *InMemoryStreamImpl) Drain() []StreamEntry {
+	i.Lock()
+	pf := i.prefetch
+	i.prefetch = nil
+	i.Unlock()
+
+	if pf == nil {
+		return nil
+	}
+
+	select {
+	case <-pf.stopCh:
+	default:
+		close(pf.stopCh)
+	}
+	<-pf.done
+	return pf.drainAll()
+}
+
+// ReceiveBatch accumulates up to maxItems entries, returning early once
+// maxWait elapses or the stream drains. If StartReadAhead is running, it's
+// served from the prefetch buffer instead of reading i.outputs directly.
+func (i *InMemoryStreamImpl) ReceiveBatch(maxItems int, maxWait time.Duration) ([]InternalReceiveResponse, ErrorType, error) {
+	if maxItems <= 0 {
+		maxItems = 1
+	}
+
+	i.RLock()
+	pf := i.prefetch
+	i.RUnlock()
+
+	if pf != nil {
+		return i.receiveBatchFromPrefetch(pf, maxItems, maxWait)
+	}
+	return i.receiveBatchFromChannel(maxItems, maxWait)
+}
+
+func (i *InMemoryStreamImpl) receiveBatchFromChannel(maxItems int, maxWait time.Duration) ([]InternalReceiveResponse, ErrorType, error) {
+	deadline := time.After(maxWait)
+	batch := make([]StreamEntry, 0, maxItems)
+
+	for len(batch) < maxItems {
+		if i.stopped && len(batch) == 0 {
+			return nil, ErrorTypeStreamStopped, ErrStreamStopped
+		}
+		i.RLock()
+		outputsChan := i.outputs
+		resetCh := i.resetCh
+		i.RUnlock()
+		if len(batch) > 0 {
+			// Once at least one entry is in hand, return as soon as outputs
+			// has nothing more buffered instead of waiting out maxWait.
+			select {
+			case entry, ok := <-outputsChan:
+				if ok {
+					batch = append(batch, entry)
+					continue
+				}
+			default:
+			}
+			return i.toReceiveResponses(batch), ErrorTypeNone, nil
+		}
+		select {
+		case entry, ok := <-outputsChan:
+			if !ok {
+				if len(batch) == 0 {
+					return nil, ErrorTypeStreamStopped, ErrStreamStopped
+				}
+				return i.toReceiveResponses(batch), ErrorTypeNone, nil
+			}
+			batch = append(batch, entry)
+		case <-i.stopCh:
+			if len(batch) == 0 {
+				return nil, ErrorTypeStreamStopped, ErrStreamStopped
+			}
+			return i.toReceiveResponses(batch), ErrorTypeNone, nil
+		case <-resetCh:
+			if len(batch) == 0 {
+				return nil, ErrorTypeStreamReset, i.resetError()
+			}
+			return i.toReceiveResponses(batch), ErrorTypeNone, nil
+		case <-deadline:
+			return i.toReceiveResponses(batch), ErrorTypeNone, nil
+		}
+	}
+	return i.toReceiveResponses(batch), ErrorTypeNone, nil
+}
+
+func (i *InMemoryStreamImpl) receiveBatchFromPrefetch(pf *prefetchBuffer, maxItems int, maxWait time.Duration) ([]InternalReceiveResponse, ErrorType, error) {
+	deadline := time.After(maxWait)
+	for {
+		entries := pf.popUpTo(maxItems)
+		if len(entries) > 0 {
+			return i.toReceiveResponses(entries), ErrorTypeNone, nil
+		}
+		if i.stopped {
+			return nil, ErrorTypeStreamStopped, ErrStreamStopped
+		}
+		i.RLock()
+		resetCh := i.resetCh
+		i.RUnlock()
+		select {
+		case <-pf.notify:
+			continue
+		case <-i.stopCh:
+			return nil, ErrorTypeStreamStopped, ErrStreamStopped
+		case <-resetCh:
+			return nil, ErrorTypeStreamReset, i.resetError()
+		case <-deadline:
+			return nil, ErrorTypeWaitingTimeout, nil
+		}
+	}
+}
+
+func (i *InMemoryStreamImpl) toReceiveResponses(entries []StreamEntry) []InternalReceiveResponse {
+	responses := make([]InternalReceiveResponse, len(entries))
+	for idx, entry := range entries {
+		if i.memoryManager != nil {
+			i.memoryManager.Release(approxEntrySize(entry.Output))
+		}
+		responses[idx] = InternalReceiveResponse{
+			OutputUuid: entry.OutputUUID,
+			Output:     entry.Output,
+			Timestamp:  entry.Timestamp,
+		}
+	}
+	return responses
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestReceiveBatchServesFromPrefetchBuffer(t *testing.T) {
+	stream := NewInMemoryStreamImpl(8).(*InMemoryStreamImpl)
+	stream.StartReadAhead(4)
+
+	for i := 0; i < 5; i++ {
+		if _, err := stream.Send("entry", uuid.New(), time.Now(), 0); err != nil {
+			t.Fatalf("Send %d: %v", i, err)
+		}
+	}
+
+	responses, errType, err := stream.ReceiveBatch(5, time.Second)
+	if err != nil || errType != ErrorTypeNone {
+		t.Fatalf("ReceiveBatch: errType=%v err=%v", errType, err)
+	}
+	if len(responses) != 5 {
+		t.Fatalf("ReceiveBatch returned %d entries, want 5", len(responses))
+	}
+}
+
+func TestDrainReturnsBufferedPrefetchedEntries(t *testing.T) {
+	stream := NewInMemoryStreamImpl(8).(*InMemoryStreamImpl)
+	stream.StartReadAhead(4)
+
+	for i := 0; i < 3; i++ {
+		if _, err := stream.Send("entry", uuid.New(), time.Now(), 0); err != nil {
+			t.Fatalf("Send %d: %v", i, err)
+		}
+	}
+
+	// Give the background prefetcher a chance to pull the sends into its
+	// buffer before Drain stops it.
+	time.Sleep(50 * time.Millisecond)
+
+	entries := stream.Drain()
+	if len(entries) != 3 {
+		t.Fatalf("Drain returned %d entries, want 3", len(entries))
+	}
+}
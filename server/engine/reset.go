@@ -0,0 +1,55 @@
+package engine
+
+import "errors"
+
+// ErrStreamReset is wrapped (together with the caller-supplied reason) by
+// the error returned from any in-flight Send/Receive that was blocked on
+// data Reset just discarded.
+var ErrStreamReset = errors.New("stream was reset")
+
+// ErrorTypeStreamReset is returned by Send/Receive once Reset has discarded
+// in-flight entries, distinguishing a deliberate discard from a graceful
+// Stop (which lets already-buffered entries be read).
+const ErrorTypeStreamReset ErrorType = "STREAM_RESET"
+
+// Reset discards all entries currently buffered in outputs without letting
+// any Receive caller consume them, and causes any Send/Receive already
+// blocked on that buffer to return an error wrapping reason. Unlike Stop,
+// Reset doesn't end the stream: Send and Receive keep working against a
+// fresh, empty buffer afterwards. This is for cases where upstream decides
+// buffered output is invalid (e.g. the workflow producing it aborted) and
+// consumers must not observe it, which Stop alone can't guarantee since it
+// leaves already-buffered entries readable.
+func (i *InMemoryStreamImpl) Reset(reason error) error {
+	i.Lock()
+	if i.stopped {
+		i.Unlock()
+		return nil
+	}
+
+	old := i.outputs
+	i.outputs = make(chan StreamEntry, i.capacity)
+
+	oldResetCh := i.resetCh
+	i.resetCh = make(chan struct{})
+	i.resetReason = reason
+	i.Unlock()
+
+	// Wake anything blocked mid-Send/Receive against the buffer we just
+	// swapped out.
+	close(oldResetCh)
+
+	// Discard whatever was buffered in the old channel without delivering
+	// it to any Receive caller; release its memory accounting since it's
+	// gone for good.
+	for {
+		select {
+		case entry := <-old:
+			if i.memoryManager != nil {
+				i.memoryManager.Release(approxEntrySize(entry.Output))
+			}
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestResetDiscardsBufferedEntriesAndWakesBlockedReceive(t *testing.T) {
+	stream := NewInMemoryStreamImpl(4).(*InMemoryStreamImpl)
+
+	if _, err := stream.Send("entry", uuid.New(), time.Now(), 0); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	reason := errors.New("upstream workflow aborted")
+	type receiveResult struct {
+		errType ErrorType
+		err     error
+	}
+	results := make(chan receiveResult, 1)
+	go func() {
+		// This Receive drains the one entry sent above, then blocks on the
+		// now-empty buffer until Reset wakes it.
+		stream.Receive(1)
+		_, errType, err := stream.Receive(1)
+		results <- receiveResult{errType: errType, err: err}
+	}()
+
+	// Give the goroutine a moment to reach its second, blocking Receive
+	// before resetting.
+	time.Sleep(20 * time.Millisecond)
+	if err := stream.Reset(reason); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+
+	select {
+	case res := <-results:
+		if !errors.Is(res.err, ErrStreamReset) {
+			t.Fatalf("Receive err = %v, want wrapping ErrStreamReset", res.err)
+		}
+		if !errors.Is(res.err, reason) {
+			t.Fatalf("Receive err = %v, want wrapping reset reason %v", res.err, reason)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Receive blocked past Reset instead of being woken")
+	}
+
+	// Entries buffered before Reset must not still be deliverable.
+	if _, err := stream.Send("stale", uuid.New(), time.Now(), 0); err != nil {
+		t.Fatalf("Send before second Reset: %v", err)
+	}
+	if err := stream.Reset(reason); err != nil {
+		t.Fatalf("second Reset: %v", err)
+	}
+	freshUUID := uuid.New()
+	if _, err := stream.Send("entry", freshUUID, time.Now(), 0); err != nil {
+		t.Fatalf("Send after Reset: %v", err)
+	}
+	resp, errType, err := stream.Receive(1)
+	if err != nil || errType != ErrorTypeNone {
+		t.Fatalf("Receive after Reset: errType=%v err=%v", errType, err)
+	}
+	if resp == nil {
+		t.Fatal("Receive after Reset returned nil response")
+	}
+	if resp.OutputUuid != freshUUID {
+		t.Fatalf("Receive after Reset = %v, want %v (entry buffered before Reset must be discarded)", resp.OutputUuid, freshUUID)
+	}
+}
@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription is the handle returned by InMemoryStreamImpl.Subscribe. Each
+// subscription tracks its own read cursor, independent of other subscribers
+// and of the single-consumer Receive on the stream itself.
+type Subscription interface {
+	// Receive returns the next entry for this subscriber, blocking up to
+	// timeoutSeconds. If this subscriber has fallen behind and the entries
+	// it needs have been evicted from the replay history, it returns
+	// ErrorTypeSubscriberLagged with the earliest UUID still available so
+	// the caller can decide how to resume.
+	Receive(timeoutSeconds int) (*InternalReceiveResponse, ErrorType, error)
+	// Close releases this subscriber's cursor. It does not affect the
+	// underlying stream or other subscribers.
+	Close()
+}
+
+// ErrSubscriberLagged is wrapped by the error returned alongside
+// ErrorTypeSubscriberLagged when a subscriber's cursor has fallen behind the
+// oldest entry still held in the replay history.
+var ErrSubscriberLagged = errors.New("subscriber lagged behind available history")
+
+// ErrorTypeSubscriberLagged is returned by Subscription.Receive when the
+// subscriber was lapped by the replay history before it could catch up.
+const ErrorTypeSubscriberLagged ErrorType = "SUBSCRIBER_LAGGED"
+
+// historyEntry pairs a StreamEntry with the monotonic sequence number it was
+// recorded under, so subscribers can detect when their cursor has been
+// overwritten.
+type historyEntry struct {
+	seq   uint64
+	entry StreamEntry
+}
+
+// subscriberState is the shared, mutex-protected state for one subscriber.
+// cursor is the sequence number of the next entry this subscriber hasn't
+// seen yet.
+type subscriberState struct {
+	id     string
+	cursor uint64
+	// buffered so Send never blocks waking a subscriber
+	signal chan struct{}
+}
+
+type subscriptionImpl struct {
+	stream *InMemoryStreamImpl
+	sub    *subscriberState
+}
+
+func (s *subscriptionImpl) Receive(timeoutSeconds int) (*InternalReceiveResponse, ErrorType, error) {
+	return s.stream.receiveForSubscriber(s.sub, timeoutSeconds)
+}
+
+func (s *subscriptionImpl) Close() {
+	s.stream.unsubscribe(s.sub.id)
+}
+
+// Subscribe registers a new fan-out subscriber. If fromUUID is non-nil and
+// still present in the replay history, the subscriber's cursor starts right
+// after it; otherwise it starts from the next entry Sent after Subscribe
+// returns.
+func (i *InMemoryStreamImpl) Subscribe(subscriberID string, fromUUID *uuid.UUID) (Subscription, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	if i.stopped {
+		return nil, ErrStreamStopped
+	}
+
+	i.ensureHistoryLocked()
+
+	cursor := i.nextSeq
+	if fromUUID != nil {
+		if seq, ok := i.seqForUUIDLocked(*fromUUID); ok {
+			cursor = seq + 1
+		}
+	}
+
+	if i.subscribers == nil {
+		i.subscribers = make(map[string]*subscriberState)
+	}
+	sub := &subscriberState{
+		id:     subscriberID,
+		cursor: cursor,
+		signal: make(chan struct{}, 1),
+	}
+	i.subscribers[subscriberID] = sub
+	return &subscriptionImpl{stream: i, sub: sub}, nil
+}
+
+func (i *InMemoryStreamImpl) unsubscribe(subscriberID string) {
+	i.Lock()
+	defer i.Unlock()
+	delete(i.subscribers, subscriberID)
+}
+
+// ensureHistoryLocked lazily sizes the replay ring to the stream's channel
+// capacity the first time it's needed (the first Send or Subscribe), so
+// streams that are never sent to or subscribed to don't pay for it. Callers
+// must hold the write lock.
+func (i *InMemoryStreamImpl) ensureHistoryLocked() {
+	if i.history != nil {
+		return
+	}
+	size := i.capacity
+	if size <= 0 {
+		size = 1
+	}
+	i.history = make([]historyEntry, size)
+}
+
+// recordHistory appends entry to the replay ring and wakes any subscribers
+// waiting on new data. It records unconditionally, not just once a
+// subscriber exists, so a stream's first Subscribe call can still replay
+// entries sent before it.
+func (i *InMemoryStreamImpl) recordHistory(entry StreamEntry) {
+	i.Lock()
+	i.ensureHistoryLocked()
+	seq := i.nextSeq
+	i.nextSeq++
+	idx := int(seq % uint64(len(i.history)))
+	i.history[idx] = historyEntry{seq: seq, entry: entry}
+	if i.nextSeq-i.oldestSeq > uint64(len(i.history)) {
+		i.oldestSeq = i.nextSeq - uint64(len(i.history))
+	}
+
+	subs := make([]*subscriberState, 0, len(i.subscribers))
+	for _, sub := range i.subscribers {
+		subs = append(subs, sub)
+	}
+	i.Unlock()
+
+	for _, sub := range subs {
+		select {
+		case sub.signal <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (i *InMemoryStreamImpl) entryAtSeq(seq uint64) (StreamEntry, bool) {
+	i.RLock()
+	defer i.RUnlock()
+	if i.history == nil || seq < i.oldestSeq || seq >= i.nextSeq {
+		return StreamEntry{}, false
+	}
+	idx := int(seq % uint64(len(i.history)))
+	return i.history[idx].entry, true
+}
+
+// seqForUUIDLocked scans the replay history for id. Callers must hold the lock.
+func (i *InMemoryStreamImpl) seqForUUIDLocked(id uuid.UUID) (uint64, bool) {
+	for seq := i.oldestSeq; seq < i.nextSeq; seq++ {
+		idx := int(seq % uint64(len(i.history)))
+		if i.history[idx].entry.OutputUUID == id {
+			return seq, true
+		}
+	}
+	return 0, false
+}
+
+// receiveForSubscriber implements Subscription.Receive for sub.
+func (i *InMemoryStreamImpl) receiveForSubscriber(sub *subscriberState, timeoutSeconds int) (*InternalReceiveResponse, ErrorType, error) {
+	deadline := time.After(time.Duration(timeoutSeconds) * time.Second)
+	for {
+		i.RLock()
+		stopped := i.stopped
+		oldest := i.oldestSeq
+		next := i.nextSeq
+		i.RUnlock()
+
+		if stopped {
+			return nil, ErrorTypeStreamStopped, ErrStreamStopped
+		}
+
+		if sub.cursor < oldest {
+			earliest, ok := i.entryAtSeq(oldest)
+			sub.cursor = oldest
+			if ok {
+				return nil, ErrorTypeSubscriberLagged, fmt.Errorf("%w: earliest available entry is %s", ErrSubscriberLagged, earliest.OutputUUID)
+			}
+			return nil, ErrorTypeSubscriberLagged, ErrSubscriberLagged
+		}
+
+		if sub.cursor < next {
+			if entry, ok := i.entryAtSeq(sub.cursor); ok {
+				sub.cursor++
+				return &InternalReceiveResponse{
+					OutputUuid: entry.OutputUUID,
+					Output:     entry.Output,
+					Timestamp:  entry.Timestamp,
+				}, ErrorTypeNone, nil
+			}
+		}
+
+		select {
+		case <-sub.signal:
+			continue
+		case <-i.stopCh:
+			return nil, ErrorTypeStreamStopped, ErrStreamStopped
+		case <-deadline:
+			return nil, ErrorTypeWaitingTimeout, nil
+		}
+	}
+}
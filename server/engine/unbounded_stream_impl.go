@@ -0,0 +1,298 @@
+package engine
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnsupportedOperation is returned by the UnboundedStreamImpl methods that
+// only InMemoryStreamImpl implements (Subscribe, Reset, ReceiveBatch):
+// UnboundedStreamImpl has no replay history or prefetch buffer to back them.
+var ErrUnsupportedOperation = errors.New("operation not supported by UnboundedStreamImpl")
+
+// ErrorTypeUnsupportedOperation is returned alongside ErrUnsupportedOperation
+// by UnboundedStreamImpl methods InMemoryStreamImpl implements but it doesn't.
+const ErrorTypeUnsupportedOperation ErrorType = "UNSUPPORTED_OPERATION"
+
+// UnboundedStreamImpl is a middle ground between the fixed-size channel modes
+// of InMemoryStreamImpl: bursty producers don't lose data or stall, but
+// steady-state memory is still bounded by maxCapacity. It keeps two small
+// channels (in, out) and moves entries between them through a
+// CircularUnboundedQueue that grows geometrically on overflow and shrinks
+// back once drained. Once the queue itself hits maxCapacity, Send falls back
+// to the same blockingWriteTimeoutSeconds / circular-buffer semantics that
+// InMemoryStreamImpl uses.
+type UnboundedStreamImpl struct {
+	in  chan StreamEntry
+	out chan StreamEntry
+
+	queue           *CircularUnboundedQueue
+	initialCapacity int
+
+	stopped bool
+	stopCh  chan struct{}
+	// closed once pumpLoop has returned, so Stop can safely touch out/queue
+	// without racing the pump
+	done chan struct{}
+
+	sync.RWMutex
+}
+
+func NewUnboundedStreamImpl(initialCapacity, maxCapacity int) InMemoeryStream {
+	chanSize := initialCapacity / 3
+	if chanSize < 1 {
+		chanSize = 1
+	}
+
+	u := &UnboundedStreamImpl{
+		in:              make(chan StreamEntry, chanSize),
+		out:             make(chan StreamEntry, chanSize),
+		queue:           NewCircularUnboundedQueue(initialCapacity, maxCapacity),
+		initialCapacity: initialCapacity,
+		stopCh:          make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+	go u.pumpLoop()
+	return u
+}
+
+// Send implements InMemoeryStream.
+func (u *UnboundedStreamImpl) Send(output OutputType, outputUuid uuid.UUID, timestamp time.Time, blockingWriteTimeoutSeconds int) (errorType ErrorType, err error) {
+	if u.isStopped() {
+		return ErrorTypeStreamStopped, ErrStreamStopped
+	}
+
+	entry := StreamEntry{
+		OutputUUID: outputUuid,
+		Output:     output,
+		Timestamp:  timestamp,
+	}
+
+	select {
+	case u.in <- entry:
+		return ErrorTypeNone, nil
+	case <-u.stopCh:
+		return ErrorTypeStreamStopped, ErrStreamStopped
+	default:
+	}
+
+	// `in` is full: grow the overflow queue instead of blocking. Drain
+	// whatever's already waiting in `in` into the queue first, so this entry
+	// can't jump ahead of older entries the pump hasn't moved over yet.
+	u.Lock()
+	if u.stopped {
+		u.Unlock()
+		return ErrorTypeStreamStopped, ErrStreamStopped
+	}
+	u.drainInLocked()
+	if !u.queue.Full() {
+		u.queue.Push(entry)
+		u.Unlock()
+		return ErrorTypeNone, nil
+	}
+	u.Unlock()
+
+	// The overflow queue is at maxCapacity: fall back to the same
+	// backpressure semantics InMemoryStreamImpl uses, applied to `in`.
+	if blockingWriteTimeoutSeconds <= 0 {
+		// UnboundedStreamImpl doesn't support Reset, so resetCh is nil and
+		// never fires. sendCircularBuffer checks isStopped while holding
+		// u's write lock, so it reads the field directly rather than going
+		// through isStopped's RLock.
+		return sendCircularBuffer(entry, u.in, u.stopCh, nil, u.initialCapacity, &u.RWMutex, func() bool { return u.stopped }, nil, nil)
+	}
+	// UnboundedStreamImpl doesn't support Reset, so resetCh/resetErr are nil,
+	// same as the sendCircularBuffer call above.
+	return sendBlockingQueue(entry, u.in, u.stopCh, nil, blockingWriteTimeoutSeconds, nil)
+}
+
+// Receive implements InMemoeryStream. It keeps reading from `out` even after
+// Stop, since Stop moves every remaining entry there before closing it:
+// Receive only reports ErrorTypeStreamStopped once `out` is both closed and
+// empty.
+func (u *UnboundedStreamImpl) Receive(timeoutSeconds int) (output *InternalReceiveResponse, errorType ErrorType, err error) {
+	timeout := time.After(time.Duration(timeoutSeconds) * time.Second)
+	for {
+		u.RLock()
+		outChan := u.out
+		u.RUnlock()
+
+		select {
+		case entry, ok := <-outChan:
+			if !ok {
+				u.RLock()
+				swapped := u.out != outChan
+				u.RUnlock()
+				if swapped {
+					// Stop moved what was left into a new `out`: read on from there.
+					continue
+				}
+				return nil, ErrorTypeStreamStopped, ErrStreamStopped
+			}
+			return &InternalReceiveResponse{
+				OutputUuid: entry.OutputUUID,
+				Output:     entry.Output,
+				Timestamp:  entry.Timestamp,
+			}, ErrorTypeNone, nil
+		case <-timeout:
+			return nil, ErrorTypeWaitingTimeout, nil
+		}
+	}
+}
+
+// Stop implements InMemoeryStream.
+func (u *UnboundedStreamImpl) Stop() error {
+	u.Lock()
+	if u.stopped {
+		u.Unlock()
+		return nil
+	}
+	u.stopped = true
+	close(u.stopCh)
+	u.Unlock()
+
+	// Wait for the pump to stop touching in/out/queue before touching them
+	// here.
+	<-u.done
+
+	// Move everything left (whatever's already buffered in `out`, plus
+	// whatever's still in the overflow queue, plus whatever's waiting in `in`
+	// for the pump) into a new `out` sized to hold
+	// all of it at once, then close that, so nothing is silently dropped the
+	// way a fixed-size non-blocking push would drop it. `in` is the producer
+	// side and is deliberately never closed here: a Send that read
+	// isStopped() as false just before this point may still be writing to
+	// it, and closing out from under a concurrent send would panic.
+	u.Lock()
+	oldOut := u.out
+	// With the pump gone and the lock held nothing else reads `in`, so at
+	// least inLen entries are there to take.
+	inLen := len(u.in)
+	remaining := len(oldOut) + u.queue.Len() + inLen
+	newOut := make(chan StreamEntry, remaining)
+drainOldOut:
+	for {
+		select {
+		case entry := <-oldOut:
+			newOut <- entry
+		default:
+			break drainOldOut
+		}
+	}
+	for {
+		entry, ok := u.queue.Pop()
+		if !ok {
+			break
+		}
+		newOut <- entry
+	}
+	// Entries still in `in` are newer than everything in the queue.
+	for ; inLen > 0; inLen-- {
+		newOut <- <-u.in
+	}
+	close(newOut)
+	u.out = newOut
+	close(oldOut)
+	u.Unlock()
+
+	return nil
+}
+
+// ReceiveBatch implements InMemoeryStream. UnboundedStreamImpl has no
+// prefetch buffer to serve batches from; call Receive in a loop instead.
+func (u *UnboundedStreamImpl) ReceiveBatch(maxItems int, maxWait time.Duration) ([]InternalReceiveResponse, ErrorType, error) {
+	return nil, ErrorTypeUnsupportedOperation, ErrUnsupportedOperation
+}
+
+// StartReadAhead implements InMemoeryStream. UnboundedStreamImpl already
+// pumps entries from `in` to `out` ahead of demand on its own, so there's no
+// separate prefetcher to start; this is a no-op.
+func (u *UnboundedStreamImpl) StartReadAhead(bufferCount int) {
+}
+
+// Subscribe implements InMemoeryStream. UnboundedStreamImpl keeps no replay
+// history, so fan-out subscription isn't supported; use InMemoryStreamImpl
+// if subscribers need it.
+func (u *UnboundedStreamImpl) Subscribe(subscriberID string, fromUUID *uuid.UUID) (Subscription, error) {
+	return nil, ErrUnsupportedOperation
+}
+
+// Reset implements InMemoeryStream. UnboundedStreamImpl's overflow queue and
+// pump loop aren't built to have their buffers swapped out from under them,
+// so discarding in-flight data isn't supported here; use Stop instead.
+func (u *UnboundedStreamImpl) Reset(reason error) error {
+	return ErrUnsupportedOperation
+}
+
+func (u *UnboundedStreamImpl) isStopped() bool {
+	u.RLock()
+	defer u.RUnlock()
+	return u.stopped
+}
+
+// pumpLoop funnels every entry through the queue (in -> queue -> out)
+// instead of ever handing `in` straight to `out`, so the queue is the single
+// point of ordering: entries leave in exactly the order they entered the
+// queue, regardless of whether they arrived via `in` or were pushed directly
+// by Send's overflow path.
+func (u *UnboundedStreamImpl) pumpLoop() {
+	defer close(u.done)
+	for {
+		if !u.flushQueueToOut() {
+			return
+		}
+
+		select {
+		case entry, ok := <-u.in:
+			if !ok {
+				return
+			}
+			u.Lock()
+			u.queue.Push(entry)
+			u.Unlock()
+		case <-u.stopCh:
+			return
+		}
+	}
+}
+
+// flushQueueToOut drains the overflow queue into `out`, blocking on `out`
+// when it's full. Returns false if the stream was stopped while draining.
+func (u *UnboundedStreamImpl) flushQueueToOut() bool {
+	for {
+		u.Lock()
+		entry, ok := u.queue.Pop()
+		u.Unlock()
+		if !ok {
+			return true
+		}
+		select {
+		case u.out <- entry:
+		case <-u.stopCh:
+			return false
+		}
+	}
+}
+
+// drainInLocked moves every entry currently buffered in `in` into the queue,
+// without blocking. Send's overflow path calls this before pushing its own
+// entry, so that entries already waiting in `in` (older, but not yet picked
+// up by pumpLoop) always land in the queue ahead of it. It stops early if
+// the queue fills up, leaving the rest safely in `in` rather than dropping
+// them. Callers must hold the write lock.
+func (u *UnboundedStreamImpl) drainInLocked() {
+	for !u.queue.Full() {
+		select {
+		case entry, ok := <-u.in:
+			if !ok {
+				return
+			}
+			u.queue.Push(entry)
+		default:
+			return
+		}
+	}
+}